@@ -2,138 +2,150 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"strings"
+	"runtime"
 	"time"
 
+	"github.com/jmoney/ghcontrib/pkg/cache"
+	"github.com/jmoney/ghcontrib/pkg/contrib"
+	"github.com/jmoney/ghcontrib/pkg/render"
+	"github.com/jmoney/ghcontrib/pkg/server"
 	"github.com/shurcooL/githubv4"
 	"golang.org/x/oauth2"
 )
 
-type repo struct {
-	NameWithOwner string
-	URL           string
-	IsPrivate     bool
-}
-
-type pullRequestContributionQuery struct {
-	User struct {
-		ContributionsCollection struct {
-			PullRequestContributions struct {
-				PageInfo struct {
-					HasNextPage bool
-					EndCursor   githubv4.String
-				}
-				Nodes []struct {
-					PullRequest struct {
-						Repository repo
-					}
-				}
-			} `graphql:"pullRequestContributions(first: 100, after: $cursor)"`
-		} `graphql:"contributionsCollection(from: $from, to: $to)"`
-	} `graphql:"user(login: $login)"`
-}
-
-type commitContributionQuery struct {
-	User struct {
-		ContributionsCollection struct {
-			CommitContributionsByRepository []struct {
-				Repository repo
-			} `graphql:"commitContributionsByRepository(maxRepositories: 100)"`
-		} `graphql:"contributionsCollection(from: $from, to: $to)"`
-	} `graphql:"user(login: $login)"`
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe(os.Args[2:])
+		return
+	}
+	runCollect(os.Args[1:])
 }
 
-func main() {
-	// CLI flags
-	username := flag.String("username", "", "GitHub username")
-	startYear := flag.Int("start", 2020, "Start year (inclusive)")
-	endYear := flag.Int("end", time.Now().Year(), "End year (inclusive)")
-	flag.Parse()
+// runCollect is the original one-shot CLI behavior: fetch one user's
+// contributions for a year range and render them to stdout.
+func runCollect(args []string) {
+	fs := flag.NewFlagSet("ghcontrib", flag.ExitOnError)
+	username := fs.String("username", "", "GitHub username")
+	startYear := fs.Int("start", 2020, "Start year (inclusive)")
+	endYear := fs.Int("end", time.Now().Year(), "End year (inclusive)")
+	contributionTypes := fs.String("contribution-types", "COMMIT,PULL_REQUEST,ISSUE,PULL_REQUEST_REVIEW,REPOSITORY", "Comma-separated repositoriesContributedTo contribution types")
+	format := fs.String("format", "json", "Output format: json, markdown, html, or template")
+	templatePath := fs.String("template", "", "Path to a Go text/template file (required when --format=template)")
+	minStars := fs.Int("min-stars", 0, "Only include repos with at least this many stargazers")
+	language := fs.String("language", "", "Only include repos whose primary language matches (case-insensitive)")
+	sortBy := fs.String("sort-by", "name", "Sort repos within each year by: stars, pushed, or name")
+	refresh := fs.Bool("refresh", false, "Bypass the on-disk cache and refetch every year")
+	concurrency := fs.Int("concurrency", runtime.NumCPU(), "Number of years to fetch concurrently")
+	maxRetries := fs.Int("max-retries", 5, "Maximum retries for a rate-limited or transient query failure")
+	fs.Parse(args)
 
 	if *username == "" {
 		log.Fatal("Missing required flag: --username")
 	}
 
+	contribTypes, err := contrib.ParseContributionTypes(*contributionTypes)
+	if err != nil {
+		log.Fatalf("Invalid --contribution-types: %v", err)
+	}
+
+	renderer, err := rendererFor(*format, *templatePath, *sortBy)
+	if err != nil {
+		log.Fatalf("Invalid --format: %v", err)
+	}
+
 	token := os.Getenv("GITHUB_TOKEN")
 	if token == "" {
 		log.Fatal("GITHUB_TOKEN environment variable is not set")
 	}
 
+	cacheDir, err := cache.DefaultDir()
+	if err != nil {
+		log.Fatalf("Failed to resolve cache directory: %v", err)
+	}
+	c, err := cache.New(cacheDir)
+	if err != nil {
+		log.Fatalf("Failed to open cache: %v", err)
+	}
+
 	src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	httpClient := oauth2.NewClient(context.Background(), src)
+	httpClient := contrib.WrapHTTPClient(oauth2.NewClient(context.Background(), src))
 	client := githubv4.NewClient(httpClient)
 
-	reposByYear := make(map[int]map[string]string)
-
-	for year := *startYear; year <= *endYear; year++ {
-		from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
-		to := time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC)
-
-		repos := make(map[string]string)
-
-		// Paginate pull request contributions
-		var prCursor *githubv4.String
-		for {
-			var q pullRequestContributionQuery
-			variables := map[string]interface{}{
-				"login":  githubv4.String(*username),
-				"from":   githubv4.DateTime{Time: from},
-				"to":     githubv4.DateTime{Time: to},
-				"cursor": prCursor,
-			}
-
-			err := client.Query(context.Background(), &q, variables)
-			if err != nil {
-				log.Fatalf("Pull request query failed for year %d: %v", year, err)
-			}
-
-			for _, node := range q.User.ContributionsCollection.PullRequestContributions.Nodes {
-				repo := node.PullRequest.Repository
-				if !repo.IsPrivate && !strings.HasPrefix(repo.NameWithOwner, *username+"/") {
-					repos[repo.NameWithOwner] = repo.URL
-				}
-			}
-
-			if !q.User.ContributionsCollection.PullRequestContributions.PageInfo.HasNextPage {
-				break
-			}
-			prCursor = &q.User.ContributionsCollection.PullRequestContributions.PageInfo.EndCursor
-		}
+	reposByYear, err := contrib.Collect(context.Background(), client, c, contrib.Options{
+		Username:          *username,
+		StartYear:         *startYear,
+		EndYear:           *endYear,
+		ContributionTypes: contribTypes,
+		MinStars:          *minStars,
+		Language:          *language,
+		Refresh:           *refresh,
+		Concurrency:       *concurrency,
+		MaxRetries:        *maxRetries,
+	})
+	if err != nil {
+		log.Fatalf("Collecting contributions: %v", err)
+	}
+
+	if err := renderer.Render(reposByYear, os.Stdout); err != nil {
+		log.Fatalf("Failed to render output: %v", err)
+	}
+}
 
-		// Commit contributions (no pagination)
-		var cq commitContributionQuery
-		commitVars := map[string]interface{}{
-			"login": githubv4.String(*username),
-			"from":  githubv4.DateTime{Time: from},
-			"to":    githubv4.DateTime{Time: to},
+// rendererFor builds the render.Renderer named by --format, reading
+// --template's file when format is "template" and applying sortBy (stars,
+// pushed, or name) to formats that render repos in a visible order.
+func rendererFor(format, templatePath, sortBy string) (render.Renderer, error) {
+	switch format {
+	case "json":
+		return render.JSON{}, nil
+	case "markdown":
+		return render.Markdown{SortBy: sortBy}, nil
+	case "html":
+		return render.HTML{SortBy: sortBy}, nil
+	case "template":
+		if templatePath == "" {
+			return nil, fmt.Errorf("--template is required when --format=template")
 		}
-		err := client.Query(context.Background(), &cq, commitVars)
+		text, err := os.ReadFile(templatePath)
 		if err != nil {
-			log.Fatalf("Commit query failed for year %d: %v", year, err)
+			return nil, fmt.Errorf("reading template: %w", err)
 		}
-
-		for _, node := range cq.User.ContributionsCollection.CommitContributionsByRepository {
-			repo := node.Repository
-			if !repo.IsPrivate && !strings.HasPrefix(repo.NameWithOwner, *username+"/") {
-				repos[repo.NameWithOwner] = repo.URL
-			}
+		tmpl, err := render.NewTemplate(string(text))
+		if err != nil {
+			return nil, err
 		}
+		tmpl.SortBy = sortBy
+		return tmpl, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q (valid: json, markdown, html, template)", format)
+	}
+}
 
-		if len(repos) > 0 {
-			reposByYear[year] = repos
-		}
+// runServe starts the HTTP server exposing GET /contrib/{username}.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("ghcontrib serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	clientID := fs.String("client-id", os.Getenv("GITHUB_OAUTH_CLIENT_ID"), "GitHub OAuth App client ID for the device flow")
+	fs.Parse(args)
+
+	if *clientID == "" {
+		log.Fatal("Missing --client-id (or GITHUB_OAUTH_CLIENT_ID)")
 	}
 
-	// Output JSON
-	jsonOutput, err := json.Marshal(reposByYear)
+	cacheDir, err := cache.DefaultDir()
+	if err != nil {
+		log.Fatalf("Failed to resolve cache directory: %v", err)
+	}
+	c, err := cache.New(cacheDir)
 	if err != nil {
-		log.Fatalf("Failed to encode JSON: %v", err)
+		log.Fatalf("Failed to open cache: %v", err)
 	}
 
-	fmt.Println(string(jsonOutput))
+	srv := server.New(c, cacheDir, *clientID)
+	log.Printf("ghcontrib serve listening on %s", *addr)
+	log.Fatal(srv.ListenAndServe(*addr))
 }
@@ -0,0 +1,140 @@
+// Package auth implements GitHub's OAuth device flow so ghcontrib's server
+// mode can authorize a user without provisioning a personal access token,
+// and caches the resulting tokens on disk per username.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	deviceCodeURL  = "https://github.com/login/device/code"
+	accessTokenURL = "https://github.com/login/oauth/access_token"
+)
+
+// DeviceCode is the response from requesting a device code, as described at
+// https://docs.github.com/en/apps/oauth-apps/building-oauth-apps/authorizing-oauth-apps#device-flow.
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// DeviceFlowClient drives the device authorization flow for a single GitHub
+// OAuth App.
+type DeviceFlowClient struct {
+	ClientID   string
+	HTTPClient *http.Client
+
+	// deviceCodeURL and accessTokenURL default to GitHub's device flow
+	// endpoints via NewDeviceFlowClient; tests in this package override
+	// them to point at an httptest.Server.
+	deviceCodeURL  string
+	accessTokenURL string
+}
+
+// NewDeviceFlowClient returns a DeviceFlowClient for clientID using
+// http.DefaultClient.
+func NewDeviceFlowClient(clientID string) *DeviceFlowClient {
+	return &DeviceFlowClient{
+		ClientID:       clientID,
+		HTTPClient:     http.DefaultClient,
+		deviceCodeURL:  deviceCodeURL,
+		accessTokenURL: accessTokenURL,
+	}
+}
+
+// RequestCode starts the device flow, returning the code the user must
+// enter at VerificationURI.
+func (d *DeviceFlowClient) RequestCode(ctx context.Context) (*DeviceCode, error) {
+	form := url.Values{"client_id": {d.ClientID}}
+
+	var dc DeviceCode
+	if err := d.postForm(ctx, d.deviceCodeURL, form, &dc); err != nil {
+		return nil, fmt.Errorf("requesting device code: %w", err)
+	}
+	if dc.Interval == 0 {
+		dc.Interval = 5
+	}
+	return &dc, nil
+}
+
+// tokenResponse is the (possibly error) body returned while polling the
+// access token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+	Interval    int    `json:"interval"`
+}
+
+// PollForToken polls the access token endpoint at dc's interval until the
+// user approves the authorization, the code expires, or ctx is canceled.
+// It honors "authorization_pending" and "slow_down" per GitHub's protocol.
+func (d *DeviceFlowClient) PollForToken(ctx context.Context, dc *DeviceCode) (string, error) {
+	form := url.Values{
+		"client_id":   {d.ClientID},
+		"device_code": {dc.DeviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+
+	interval := time.Duration(dc.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("device code expired before authorization")
+		}
+
+		var tr tokenResponse
+		if err := d.postForm(ctx, d.accessTokenURL, form, &tr); err != nil {
+			return "", fmt.Errorf("polling for access token: %w", err)
+		}
+
+		switch tr.Error {
+		case "":
+			return tr.AccessToken, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			if tr.Interval > 0 {
+				interval = time.Duration(tr.Interval) * time.Second
+			} else {
+				interval += 5 * time.Second
+			}
+			continue
+		default:
+			return "", fmt.Errorf("device flow authorization failed: %s", tr.Error)
+		}
+	}
+}
+
+func (d *DeviceFlowClient) postForm(ctx context.Context, endpoint string, form url.Values, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
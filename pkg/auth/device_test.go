@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestDeviceFlowClient(deviceCodeURL, accessTokenURL string) *DeviceFlowClient {
+	return &DeviceFlowClient{
+		ClientID:       "test-client-id",
+		HTTPClient:     http.DefaultClient,
+		deviceCodeURL:  deviceCodeURL,
+		accessTokenURL: accessTokenURL,
+	}
+}
+
+func TestRequestCodeDefaultsInterval(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(DeviceCode{
+			DeviceCode:      "device-code",
+			UserCode:        "ABCD-1234",
+			VerificationURI: "https://github.com/login/device",
+			ExpiresIn:       900,
+		})
+	}))
+	defer server.Close()
+
+	d := newTestDeviceFlowClient(server.URL, "")
+	dc, err := d.RequestCode(context.Background())
+	if err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	if dc.Interval != 5 {
+		t.Fatalf("Interval = %d, want the default of 5 when GitHub omits it", dc.Interval)
+	}
+	if dc.UserCode != "ABCD-1234" {
+		t.Fatalf("UserCode = %q, want %q", dc.UserCode, "ABCD-1234")
+	}
+}
+
+func TestPollForTokenSucceedsAfterAuthorizationPending(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		if attempts < 3 {
+			json.NewEncoder(w).Encode(tokenResponse{Error: "authorization_pending"})
+			return
+		}
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "the-access-token"})
+	}))
+	defer server.Close()
+
+	d := newTestDeviceFlowClient("", server.URL)
+	dc := &DeviceCode{DeviceCode: "device-code", Interval: 0, ExpiresIn: 60}
+
+	token, err := d.PollForToken(context.Background(), dc)
+	if err != nil {
+		t.Fatalf("PollForToken: %v", err)
+	}
+	if token != "the-access-token" {
+		t.Fatalf("token = %q, want %q", token, "the-access-token")
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 poll attempts, got %d", attempts)
+	}
+}
+
+func TestPollForTokenHonorsSlowDownInterval(t *testing.T) {
+	var pollTimes []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pollTimes = append(pollTimes, time.Now())
+		w.Header().Set("Content-Type", "application/json")
+		switch len(pollTimes) {
+		case 1:
+			json.NewEncoder(w).Encode(tokenResponse{Error: "slow_down", Interval: 1})
+		default:
+			json.NewEncoder(w).Encode(tokenResponse{AccessToken: "the-access-token"})
+		}
+	}))
+	defer server.Close()
+
+	d := newTestDeviceFlowClient("", server.URL)
+	dc := &DeviceCode{DeviceCode: "device-code", Interval: 0, ExpiresIn: 60}
+
+	start := time.Now()
+	token, err := d.PollForToken(context.Background(), dc)
+	if err != nil {
+		t.Fatalf("PollForToken: %v", err)
+	}
+	if token != "the-access-token" {
+		t.Fatalf("token = %q, want %q", token, "the-access-token")
+	}
+	if len(pollTimes) != 2 {
+		t.Fatalf("expected 2 poll attempts, got %d", len(pollTimes))
+	}
+	if gap := pollTimes[1].Sub(pollTimes[0]); gap < time.Second {
+		t.Fatalf("slow_down's Interval=1 should have pushed the next poll out by >= 1s, got %s", gap)
+	}
+	if total := time.Since(start); total < time.Second {
+		t.Fatalf("expected PollForToken to take at least 1s honoring slow_down, took %s", total)
+	}
+}
+
+func TestPollForTokenReturnsErrorOnDeniedAuthorization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tokenResponse{Error: "access_denied"})
+	}))
+	defer server.Close()
+
+	d := newTestDeviceFlowClient("", server.URL)
+	dc := &DeviceCode{DeviceCode: "device-code", Interval: 0, ExpiresIn: 60}
+
+	if _, err := d.PollForToken(context.Background(), dc); err == nil {
+		t.Fatalf("expected an error when GitHub reports access_denied")
+	}
+}
+
+func TestPollForTokenReturnsErrorAfterExpiry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tokenResponse{Error: "authorization_pending"})
+	}))
+	defer server.Close()
+
+	d := newTestDeviceFlowClient("", server.URL)
+	dc := &DeviceCode{DeviceCode: "device-code", Interval: 0, ExpiresIn: 0}
+
+	if _, err := d.PollForToken(context.Background(), dc); err == nil {
+		t.Fatalf("expected an error once the device code's ExpiresIn has elapsed")
+	}
+}
+
+func TestPollForTokenRespectsContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tokenResponse{Error: "authorization_pending"})
+	}))
+	defer server.Close()
+
+	d := newTestDeviceFlowClient("", server.URL)
+	dc := &DeviceCode{DeviceCode: "device-code", Interval: 0, ExpiresIn: 60}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := d.PollForToken(ctx, dc); err == nil {
+		t.Fatalf("expected an error when ctx is already canceled")
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tokenFile is the on-disk shape of a cached token.
+type tokenFile struct {
+	AccessToken string `json:"access_token"`
+}
+
+// SaveToken writes username's access token under dir, so a future server
+// request can authorize without repeating the device flow.
+func SaveToken(dir, username, token string) error {
+	if err := os.MkdirAll(filepath.Join(dir, "tokens"), 0o700); err != nil {
+		return fmt.Errorf("creating token cache dir: %w", err)
+	}
+	data, err := json.Marshal(tokenFile{AccessToken: token})
+	if err != nil {
+		return fmt.Errorf("encoding token for %s: %w", username, err)
+	}
+	path, err := tokenPath(dir, username)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// LoadToken reads username's cached access token, returning ("", nil) if
+// none has been saved yet.
+func LoadToken(dir, username string) (string, error) {
+	path, err := tokenPath(dir, username)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading token for %s: %w", username, err)
+	}
+	var tf tokenFile
+	if err := json.Unmarshal(data, &tf); err != nil {
+		return "", fmt.Errorf("decoding token for %s: %w", username, err)
+	}
+	return tf.AccessToken, nil
+}
+
+// tokenPath builds username's token file path, rejecting any username that
+// would escape the tokens directory (e.g. containing "/", "\", or "..")
+// since usernames here come directly from untrusted HTTP query parameters.
+func tokenPath(dir, username string) (string, error) {
+	if username == "" || username == "." || username == ".." || strings.ContainsAny(username, `/\`) {
+		return "", fmt.Errorf("invalid username %q", username)
+	}
+	return filepath.Join(dir, "tokens", username+".json"), nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import "testing"
+
+func TestSaveTokenLoadTokenRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := SaveToken(dir, "jmoney", "secret-token"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	got, err := LoadToken(dir, "jmoney")
+	if err != nil {
+		t.Fatalf("LoadToken: %v", err)
+	}
+	if got != "secret-token" {
+		t.Fatalf("LoadToken = %q, want %q", got, "secret-token")
+	}
+}
+
+func TestLoadTokenMissingReturnsEmptyNotError(t *testing.T) {
+	got, err := LoadToken(t.TempDir(), "jmoney")
+	if err != nil {
+		t.Fatalf("LoadToken: %v", err)
+	}
+	if got != "" {
+		t.Fatalf("LoadToken for an unsaved username = %q, want empty", got)
+	}
+}
+
+func TestTokenPathRejectsPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	usernames := []string{
+		"../../etc/passwd",
+		"..",
+		".",
+		"",
+		"a/b",
+		`a\b`,
+		"../escape",
+	}
+
+	for _, username := range usernames {
+		if _, err := tokenPath(dir, username); err == nil {
+			t.Errorf("tokenPath(%q) = nil error, want rejection", username)
+		}
+		if err := SaveToken(dir, username, "token"); err == nil {
+			t.Errorf("SaveToken(%q) = nil error, want rejection", username)
+		}
+		if _, err := LoadToken(dir, username); err == nil {
+			t.Errorf("LoadToken(%q) = nil error, want rejection", username)
+		}
+	}
+}
+
+func TestTokenPathStaysUnderTokensDir(t *testing.T) {
+	dir := t.TempDir()
+	path, err := tokenPath(dir, "jmoney")
+	if err != nil {
+		t.Fatalf("tokenPath: %v", err)
+	}
+	want := dir + "/tokens/jmoney.json"
+	if path != want {
+		t.Fatalf("tokenPath = %q, want %q", path, want)
+	}
+}
@@ -0,0 +1,72 @@
+// Package cache stores raw GraphQL query responses on disk so ghcontrib can
+// avoid re-fetching years whose contributions can no longer change, keeping
+// large --start..--end backfills under GitHub's rate limit.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Cache reads and writes query results under a directory on disk, one file
+// per (username, year, queryType, cursor) key.
+type Cache struct {
+	dir string
+}
+
+// DefaultDir returns ~/.cache/ghcontrib, creating it if necessary.
+func DefaultDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user cache dir: %w", err)
+	}
+	return filepath.Join(userCacheDir, "ghcontrib"), nil
+}
+
+// New opens (creating if needed) a Cache rooted at dir.
+func New(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache dir %s: %w", dir, err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Key builds the cache key for one GraphQL call. queryType distinguishes
+// the three query shapes ghcontrib issues per year (pullRequest, commit,
+// repositoriesContributedTo); cursor is "" for a query's first page.
+func Key(username string, year int, queryType, cursor string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s|%s", username, year, queryType, cursor)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get reads the cached value for key into dest, returning false if there is
+// no cache entry yet.
+func (c *Cache) Get(key string, dest interface{}) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading cache entry %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, fmt.Errorf("decoding cache entry %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Put stores value under key, overwriting any existing entry.
+func (c *Cache) Put(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding cache entry %s: %w", key, err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, key+".json"), data, 0o644); err != nil {
+		return fmt.Errorf("writing cache entry %s: %w", key, err)
+	}
+	return nil
+}
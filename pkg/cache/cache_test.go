@@ -0,0 +1,68 @@
+package cache
+
+import "testing"
+
+func TestKeyIsDeterministicAndDistinguishesInputs(t *testing.T) {
+	a := Key("jmoney", 2023, "pullRequest", "")
+	b := Key("jmoney", 2023, "pullRequest", "")
+	if a != b {
+		t.Fatalf("Key should be deterministic: got %q and %q for identical inputs", a, b)
+	}
+
+	variants := []string{
+		Key("other", 2023, "pullRequest", ""),
+		Key("jmoney", 2024, "pullRequest", ""),
+		Key("jmoney", 2023, "commit", ""),
+		Key("jmoney", 2023, "pullRequest", "cursor1"),
+	}
+	for _, v := range variants {
+		if v == a {
+			t.Fatalf("Key collided across distinct inputs: %q", a)
+		}
+	}
+}
+
+func TestPutGetRoundTrip(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	type payload struct {
+		Name string
+	}
+	key := Key("jmoney", 2023, "pullRequest", "")
+	want := payload{Name: "jmoney/ghcontrib"}
+
+	if err := c.Put(key, want); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	var got payload
+	hit, err := c.Get(key, &got)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !hit {
+		t.Fatalf("expected cache hit after Put")
+	}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestGetMissReturnsFalseNotError(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var dest struct{ Name string }
+	hit, err := c.Get(Key("jmoney", 2023, "pullRequest", ""), &dest)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if hit {
+		t.Fatalf("expected cache miss for an unwritten key")
+	}
+}
@@ -0,0 +1,394 @@
+// Package contrib collects a GitHub user's per-year contributed-to
+// repositories, caching results on disk and logging rate-limit budget along
+// the way. It backs both the one-shot CLI and the serve subcommand.
+package contrib
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoney/ghcontrib/pkg/cache"
+	"github.com/jmoney/ghcontrib/pkg/render"
+	"github.com/shurcooL/githubv4"
+)
+
+// rateLimitInfo mirrors GitHub's rateLimit query fragment, included on every
+// query so large backfills can be paced against the remaining budget.
+type rateLimitInfo struct {
+	Remaining int
+	ResetAt   githubv4.DateTime
+	Cost      int
+}
+
+// rateLimited is implemented by every query struct below so fetchWithCache
+// can log rate-limit budget without caring which query ran.
+type rateLimited interface {
+	rateLimit() rateLimitInfo
+}
+
+type pullRequestContributionQuery struct {
+	RateLimit rateLimitInfo `graphql:"rateLimit"`
+	User      struct {
+		ContributionsCollection struct {
+			PullRequestContributions struct {
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   githubv4.String
+				}
+				Nodes []struct {
+					PullRequest struct {
+						Repository render.Repo
+					}
+				}
+			} `graphql:"pullRequestContributions(first: 100, after: $cursor)"`
+		} `graphql:"contributionsCollection(from: $from, to: $to)"`
+	} `graphql:"user(login: $login)"`
+}
+
+type commitContributionQuery struct {
+	RateLimit rateLimitInfo `graphql:"rateLimit"`
+	User      struct {
+		ContributionsCollection struct {
+			CommitContributionsByRepository []struct {
+				Repository render.Repo
+			} `graphql:"commitContributionsByRepository(maxRepositories: 100)"`
+		} `graphql:"contributionsCollection(from: $from, to: $to)"`
+	} `graphql:"user(login: $login)"`
+}
+
+// repositoriesContributedToQuery covers contribution types the pull request
+// and commit queries miss, such as filed issues, PR reviews, and repos the
+// user created outright.
+type repositoriesContributedToQuery struct {
+	RateLimit rateLimitInfo `graphql:"rateLimit"`
+	User      struct {
+		RepositoriesContributedTo struct {
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   githubv4.String
+			}
+			Nodes []render.Repo
+		} `graphql:"repositoriesContributedTo(first: 100, after: $cursor, contributionTypes: $contributionTypes)"`
+	} `graphql:"user(login: $login)"`
+}
+
+func (q pullRequestContributionQuery) rateLimit() rateLimitInfo   { return q.RateLimit }
+func (q commitContributionQuery) rateLimit() rateLimitInfo        { return q.RateLimit }
+func (q repositoriesContributedToQuery) rateLimit() rateLimitInfo { return q.RateLimit }
+
+// ContributionTypeNames are the GraphQL RepositoryContributionType enum
+// values accepted for Options.ContributionTypes.
+var ContributionTypeNames = []string{
+	"COMMIT",
+	"ISSUE",
+	"PULL_REQUEST",
+	"PULL_REQUEST_REVIEW",
+	"REPOSITORY",
+}
+
+// ParseContributionTypes validates and converts a comma-separated list of
+// contribution type names into the enum values the GraphQL API expects.
+func ParseContributionTypes(csv string) ([]githubv4.RepositoryContributionType, error) {
+	var types []githubv4.RepositoryContributionType
+	for _, raw := range strings.Split(csv, ",") {
+		name := strings.ToUpper(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		valid := false
+		for _, known := range ContributionTypeNames {
+			if name == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown contribution type %q (valid: %s)", raw, strings.Join(ContributionTypeNames, ", "))
+		}
+		types = append(types, githubv4.RepositoryContributionType(name))
+	}
+	return types, nil
+}
+
+// Options configures a Collect call.
+type Options struct {
+	Username           string
+	StartYear, EndYear int
+	ContributionTypes  []githubv4.RepositoryContributionType
+	MinStars           int
+	Language           string
+	Refresh            bool
+	// Concurrency bounds how many years are fetched at once. Zero or
+	// negative defaults to runtime.NumCPU().
+	Concurrency int
+	// MaxRetries bounds how many times a query is retried after a
+	// secondary rate limit or transient network error.
+	MaxRetries int
+}
+
+// Collect fetches opts.Username's contributed-to repositories for each year
+// in [StartYear, EndYear], merging pull request, commit, and
+// repositoriesContributedTo results and applying MinStars/Language
+// filtering. repositoriesContributedTo results carry no per-contribution
+// date, so they are all attributed to the current year regardless of where
+// it falls relative to [StartYear, EndYear]; callers should treat that
+// year's results as not fully year-accurate. Results for past years are
+// served from c unless Refresh is set; the current year is always
+// refetched. Years are fetched concurrently across a worker pool bounded by
+// opts.Concurrency.
+func Collect(ctx context.Context, client *githubv4.Client, c *cache.Cache, opts Options) (map[int]map[string]render.Repo, error) {
+	return collect(ctx, client, c, opts, time.Now().Year())
+}
+
+// collect is Collect with currentYear taken as a parameter rather than
+// time.Now().Year(), so tests can exercise year-bucketing deterministically.
+func collect(ctx context.Context, client *githubv4.Client, c *cache.Cache, opts Options, currentYear int) (map[int]map[string]render.Repo, error) {
+	if len(opts.ContributionTypes) == 0 {
+		for _, name := range ContributionTypeNames {
+			opts.ContributionTypes = append(opts.ContributionTypes, githubv4.RepositoryContributionType(name))
+		}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	years := make([]int, 0, opts.EndYear-opts.StartYear+1)
+	for year := opts.StartYear; year <= opts.EndYear; year++ {
+		years = append(years, year)
+	}
+
+	// repositoriesContributedTo has no from/to range and GitHub exposes no
+	// per-contribution-type date on this connection, so there's no year to
+	// bucket these repos by honestly. They're all attributed to currentYear
+	// rather than PushedAt (the repo's last push by anyone, not the user's
+	// contribution), which would misfile old reviews/issues under whichever
+	// year someone else last pushed and silently drop them when that year
+	// falls outside [StartYear, EndYear].
+	globalRepos, err := fetchRepositoriesContributedTo(ctx, client, c, opts, currentYear)
+	if err != nil {
+		return nil, err
+	}
+
+	reposByYear := make(map[int]map[string]render.Repo)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	errs := make(chan error, len(years))
+
+	for _, year := range years {
+		year := year
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			repos, err := collectYear(ctx, client, c, opts, year, currentYear)
+			if err != nil {
+				errs <- fmt.Errorf("year %d: %w", year, err)
+				return
+			}
+			if year == currentYear {
+				for name, repo := range globalRepos {
+					repos[name] = repo
+				}
+			}
+			repos = filterRepos(repos, opts.MinStars, opts.Language)
+			if len(repos) == 0 {
+				return
+			}
+			mu.Lock()
+			reposByYear[year] = repos
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// If currentYear falls outside the requested range, its goroutine never
+	// ran above, but repositoriesContributedTo results are still attributed
+	// to it; merge them in here instead of silently dropping them.
+	if currentYear < opts.StartYear || currentYear > opts.EndYear {
+		repos := filterRepos(globalRepos, opts.MinStars, opts.Language)
+		if len(repos) > 0 {
+			reposByYear[currentYear] = repos
+		}
+	}
+
+	return reposByYear, nil
+}
+
+// collectYear fetches and merges one year's pull request and commit
+// contributions. repositoriesContributedTo is fetched separately, once per
+// Collect call, since it has no from/to range to scope it by year.
+func collectYear(ctx context.Context, client *githubv4.Client, c *cache.Cache, opts Options, year, currentYear int) (map[string]render.Repo, error) {
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	repos := make(map[string]render.Repo)
+
+	// Paginate pull request contributions
+	var prCursor *githubv4.String
+	for {
+		var q pullRequestContributionQuery
+		variables := map[string]interface{}{
+			"login":  githubv4.String(opts.Username),
+			"from":   githubv4.DateTime{Time: from},
+			"to":     githubv4.DateTime{Time: to},
+			"cursor": prCursor,
+		}
+
+		cursor := cursorString(prCursor)
+		err := withRetry(ctx, opts.MaxRetries, year, "pullRequest", func() error {
+			return fetchWithCache(ctx, client, c, opts.Username, year, currentYear, opts.Refresh, "pullRequest", cursor, variables, &q)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("pull request query failed: %w", err)
+		}
+
+		for _, node := range q.User.ContributionsCollection.PullRequestContributions.Nodes {
+			repo := node.PullRequest.Repository
+			if !repo.IsPrivate && !strings.HasPrefix(repo.NameWithOwner, opts.Username+"/") {
+				repos[repo.NameWithOwner] = repo
+			}
+		}
+
+		if !q.User.ContributionsCollection.PullRequestContributions.PageInfo.HasNextPage {
+			break
+		}
+		prCursor = &q.User.ContributionsCollection.PullRequestContributions.PageInfo.EndCursor
+	}
+
+	// Commit contributions (no pagination)
+	var cq commitContributionQuery
+	commitVars := map[string]interface{}{
+		"login": githubv4.String(opts.Username),
+		"from":  githubv4.DateTime{Time: from},
+		"to":    githubv4.DateTime{Time: to},
+	}
+	err := withRetry(ctx, opts.MaxRetries, year, "commit", func() error {
+		return fetchWithCache(ctx, client, c, opts.Username, year, currentYear, opts.Refresh, "commit", "", commitVars, &cq)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("commit query failed: %w", err)
+	}
+
+	for _, node := range cq.User.ContributionsCollection.CommitContributionsByRepository {
+		repo := node.Repository
+		if !repo.IsPrivate && !strings.HasPrefix(repo.NameWithOwner, opts.Username+"/") {
+			repos[repo.NameWithOwner] = repo
+		}
+	}
+
+	return repos, nil
+}
+
+// fetchRepositoriesContributedTo paginates repositoriesContributedTo for the
+// whole Collect call. Unlike the pull request and commit queries, this
+// connection takes no from/to range, so it can only be fetched once; the
+// caller attributes all of its results to currentYear, since GitHub exposes
+// no per-contribution-type date to bucket them by more precisely. It's
+// cached and retried under currentYear too, since an unscoped query is never
+// safe to treat as an immutable past year.
+func fetchRepositoriesContributedTo(ctx context.Context, client *githubv4.Client, c *cache.Cache, opts Options, currentYear int) (map[string]render.Repo, error) {
+	repos := make(map[string]render.Repo)
+
+	var cursor *githubv4.String
+	for {
+		var rq repositoriesContributedToQuery
+		variables := map[string]interface{}{
+			"login":             githubv4.String(opts.Username),
+			"cursor":            cursor,
+			"contributionTypes": opts.ContributionTypes,
+		}
+
+		cursorStr := cursorString(cursor)
+		err := withRetry(ctx, opts.MaxRetries, currentYear, "repositoriesContributedTo", func() error {
+			return fetchWithCache(ctx, client, c, opts.Username, currentYear, currentYear, opts.Refresh, "repositoriesContributedTo", cursorStr, variables, &rq)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("repositoriesContributedTo query failed: %w", err)
+		}
+
+		for _, repo := range rq.User.RepositoriesContributedTo.Nodes {
+			if !repo.IsPrivate && !strings.HasPrefix(repo.NameWithOwner, opts.Username+"/") {
+				repos[repo.NameWithOwner] = repo
+			}
+		}
+
+		if !rq.User.RepositoriesContributedTo.PageInfo.HasNextPage {
+			break
+		}
+		cursor = &rq.User.RepositoriesContributedTo.PageInfo.EndCursor
+	}
+
+	return repos, nil
+}
+
+// filterRepos drops repos below minStars or whose primary language doesn't
+// match language (case-insensitive); an empty language matches everything.
+func filterRepos(repos map[string]render.Repo, minStars int, language string) map[string]render.Repo {
+	if minStars == 0 && language == "" {
+		return repos
+	}
+	filtered := make(map[string]render.Repo, len(repos))
+	for name, repo := range repos {
+		if repo.StargazerCount < minStars {
+			continue
+		}
+		if language != "" && !strings.EqualFold(repo.PrimaryLanguage.Name, language) {
+			continue
+		}
+		filtered[name] = repo
+	}
+	return filtered
+}
+
+// fetchWithCache runs one GraphQL query, serving it from c when year is in
+// the past (and thus immutable) unless refresh is set. A fresh result is
+// always written back to the cache and has its rate-limit budget logged.
+func fetchWithCache(ctx context.Context, client *githubv4.Client, c *cache.Cache, username string, year, currentYear int, refresh bool, queryType, cursor string, variables map[string]interface{}, dest rateLimited) error {
+	key := cache.Key(username, year, queryType, cursor)
+
+	if !refresh && year < currentYear {
+		hit, err := c.Get(key, dest)
+		if err != nil {
+			return err
+		}
+		if hit {
+			return nil
+		}
+	}
+
+	if err := client.Query(ctx, dest, variables); err != nil {
+		return err
+	}
+	if err := c.Put(key, dest); err != nil {
+		return err
+	}
+
+	rl := dest.rateLimit()
+	log.Printf("rate limit: %d remaining (last call cost %d), resets at %s", rl.Remaining, rl.Cost, rl.ResetAt.Time.Format(time.RFC3339))
+	return nil
+}
+
+// cursorString renders a pagination cursor for use in a cache key, treating
+// a nil cursor (the first page) as the empty string.
+func cursorString(cursor *githubv4.String) string {
+	if cursor == nil {
+		return ""
+	}
+	return string(*cursor)
+}
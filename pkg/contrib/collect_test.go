@@ -0,0 +1,187 @@
+package contrib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jmoney/ghcontrib/pkg/cache"
+	"github.com/jmoney/ghcontrib/pkg/render"
+	"github.com/shurcooL/githubv4"
+)
+
+func TestFilterReposByMinStars(t *testing.T) {
+	repos := map[string]render.Repo{
+		"a/low":  {NameWithOwner: "a/low", StargazerCount: 1},
+		"a/high": {NameWithOwner: "a/high", StargazerCount: 100},
+	}
+
+	got := filterRepos(repos, 10, "")
+	if _, ok := got["a/low"]; ok {
+		t.Fatalf("expected a/low to be filtered out by MinStars")
+	}
+	if _, ok := got["a/high"]; !ok {
+		t.Fatalf("expected a/high to survive MinStars filter")
+	}
+}
+
+func TestFilterReposByLanguageCaseInsensitive(t *testing.T) {
+	repos := map[string]render.Repo{
+		"a/go": {NameWithOwner: "a/go"},
+		"a/rs": {NameWithOwner: "a/rs"},
+	}
+	repos["a/go"] = withLanguage(repos["a/go"], "Go")
+	repos["a/rs"] = withLanguage(repos["a/rs"], "Rust")
+
+	got := filterRepos(repos, 0, "go")
+	if len(got) != 1 {
+		t.Fatalf("expected exactly 1 repo to match language \"go\", got %d", len(got))
+	}
+	if _, ok := got["a/go"]; !ok {
+		t.Fatalf("expected a/go to match language filter case-insensitively")
+	}
+}
+
+func TestFilterReposNoOpWhenUnfiltered(t *testing.T) {
+	repos := map[string]render.Repo{"a/b": {NameWithOwner: "a/b"}}
+	got := filterRepos(repos, 0, "")
+	if len(got) != len(repos) {
+		t.Fatalf("expected filterRepos to pass every repo through unfiltered")
+	}
+}
+
+func withLanguage(r render.Repo, lang string) render.Repo {
+	r.PrimaryLanguage.Name = lang
+	return r
+}
+
+// fakeGraphQLServer answers every request with the JSON body registered for
+// whichever of the substrings in responses is found in the request's query
+// string, letting a test stand in for the whole pullRequest/commit/
+// repositoriesContributedTo split without caring about exact query shape.
+func fakeGraphQLServer(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding GraphQL request: %v", err)
+		}
+		for marker, resp := range responses {
+			if strings.Contains(body.Query, marker) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(resp))
+				return
+			}
+		}
+		t.Fatalf("no fake response registered for query: %s", body.Query)
+	}))
+}
+
+const emptyRateLimit = `"rateLimit":{"remaining":5000,"resetAt":"2026-01-01T00:00:00Z","cost":1}`
+
+func emptyPullRequestResponse() string {
+	return `{"data":{` + emptyRateLimit + `,"user":{"contributionsCollection":{"pullRequestContributions":{"pageInfo":{"hasNextPage":false,"endCursor":""},"nodes":[]}}}}}`
+}
+
+func emptyCommitResponse() string {
+	return `{"data":{` + emptyRateLimit + `,"user":{"contributionsCollection":{"commitContributionsByRepository":[]}}}}`
+}
+
+func repositoriesContributedToResponse(nameWithOwner string) string {
+	return `{"data":{` + emptyRateLimit + `,"user":{"repositoriesContributedTo":{"pageInfo":{"hasNextPage":false,"endCursor":""},"nodes":[{"nameWithOwner":"` + nameWithOwner + `","url":"https://github.com/` + nameWithOwner + `","isPrivate":false,"description":"","stargazerCount":0,"primaryLanguage":null,"owner":{"login":"owner","avatarUrl":""},"pushedAt":"2019-01-01T00:00:00Z"}]}}}}`
+}
+
+// TestCollectAttributesRepositoriesContributedToToCurrentYear guards against
+// bucketing repositoriesContributedTo results by PushedAt: a repo last
+// pushed by someone else in 2019 that the user merely reviewed must still
+// show up, filed under the current year, even when the requested range
+// doesn't include the repo's PushedAt year.
+func TestCollectAttributesRepositoriesContributedToToCurrentYear(t *testing.T) {
+	currentYear := 2026
+	server := fakeGraphQLServer(t, map[string]string{
+		"repositoriesContributedTo":       repositoriesContributedToResponse("octocat/old-repo"),
+		"pullRequestContributions":        emptyPullRequestResponse(),
+		"commitContributionsByRepository": emptyCommitResponse(),
+	})
+	defer server.Close()
+
+	client := githubv4.NewEnterpriseClient(server.URL, server.Client())
+	c, err := cache.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("cache.New: %v", err)
+	}
+
+	opts := Options{
+		Username:  "jmoney",
+		StartYear: currentYear - 2,
+		EndYear:   currentYear,
+	}
+
+	reposByYear, err := collect(context.Background(), client, c, opts, currentYear)
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	repos, ok := reposByYear[currentYear]
+	if !ok {
+		t.Fatalf("expected repositoriesContributedTo repo to be filed under currentYear %d, got years %v", currentYear, keys(reposByYear))
+	}
+	if _, ok := repos["octocat/old-repo"]; !ok {
+		t.Fatalf("expected octocat/old-repo in currentYear bucket, got %v", repos)
+	}
+
+	if _, ok := reposByYear[2019]; ok {
+		t.Fatalf("repositoriesContributedTo repo must not be bucketed by its PushedAt year")
+	}
+}
+
+// TestCollectAttributesRepositoriesContributedToOutsideRequestedRange covers
+// the case the review flagged: a requested range that excludes currentYear
+// must still surface the repo rather than silently dropping it.
+func TestCollectAttributesRepositoriesContributedToOutsideRequestedRange(t *testing.T) {
+	currentYear := 2026
+	server := fakeGraphQLServer(t, map[string]string{
+		"repositoriesContributedTo":       repositoriesContributedToResponse("octocat/old-repo"),
+		"pullRequestContributions":        emptyPullRequestResponse(),
+		"commitContributionsByRepository": emptyCommitResponse(),
+	})
+	defer server.Close()
+
+	client := githubv4.NewEnterpriseClient(server.URL, server.Client())
+	c, err := cache.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("cache.New: %v", err)
+	}
+
+	opts := Options{
+		Username:  "jmoney",
+		StartYear: currentYear - 5,
+		EndYear:   currentYear - 3,
+	}
+
+	reposByYear, err := collect(context.Background(), client, c, opts, currentYear)
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	repos, ok := reposByYear[currentYear]
+	if !ok {
+		t.Fatalf("expected repositoriesContributedTo repo to surface under currentYear even outside the requested range, got years %v", keys(reposByYear))
+	}
+	if _, ok := repos["octocat/old-repo"]; !ok {
+		t.Fatalf("expected octocat/old-repo in currentYear bucket, got %v", repos)
+	}
+}
+
+func keys(m map[int]map[string]render.Repo) []int {
+	ks := make([]int, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
@@ -0,0 +1,186 @@
+package contrib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secondaryRateLimitError is returned by rateLimitRoundTripper when GitHub
+// responds with its abuse/secondary rate limit (HTTP 403 plus a
+// Retry-After header), so withRetry can honor the exact wait time.
+type secondaryRateLimitError struct {
+	retryAfter time.Duration
+}
+
+func (e *secondaryRateLimitError) Error() string {
+	return fmt.Sprintf("secondary rate limit hit, retry after %s", e.retryAfter)
+}
+
+// primaryRateLimitError is returned by rateLimitRoundTripper when a GraphQL
+// response body carries an error of type RATE_LIMITED. shurcooL/graphql's
+// own error type only preserves the "message" field, dropping the "type"
+// GitHub uses to distinguish a rate limit from any other GraphQL error, so
+// isRetryable can't tell them apart from the error githubv4.Client.Query
+// returns; this is detected at the transport layer instead, from the raw
+// response body.
+type primaryRateLimitError struct {
+	message string
+}
+
+func (e *primaryRateLimitError) Error() string {
+	return fmt.Sprintf("graphql: RATE_LIMITED: %s", e.message)
+}
+
+// graphQLErrorBody is the subset of a GraphQL response's "errors" array
+// needed to detect a RATE_LIMITED error, per
+// https://docs.github.com/en/graphql/overview/rate-limits-and-node-limits-for-the-graphql-api.
+type graphQLErrorBody struct {
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// rateLimitRoundTripper wraps an http.RoundTripper so GitHub's rate limit
+// responses surface as retryable errors instead of being handed to the
+// GraphQL client as an opaque HTTP error or an untyped GraphQL error: a 403
+// carrying a Retry-After header becomes a secondaryRateLimitError, and a 200
+// response whose body contains a RATE_LIMITED GraphQL error becomes a
+// primaryRateLimitError.
+type rateLimitRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (rt *rateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		retryAfter := resp.Header.Get("Retry-After")
+		wait, ok := parseRetryAfterSeconds(retryAfter)
+		if !ok {
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, &secondaryRateLimitError{retryAfter: wait}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var errBody graphQLErrorBody
+	if json.Unmarshal(body, &errBody) == nil {
+		for _, e := range errBody.Errors {
+			if e.Type == "RATE_LIMITED" {
+				return nil, &primaryRateLimitError{message: e.Message}
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// WrapHTTPClient returns a shallow copy of client whose Transport detects
+// GitHub's abuse/secondary rate limit (HTTP 403 with a Retry-After header)
+// and surfaces it as a retryable error instead of an opaque HTTP failure.
+func WrapHTTPClient(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &rateLimitRoundTripper{next: next}
+	return &wrapped
+}
+
+// isRetryable reports whether err is worth retrying: GitHub's abuse/
+// secondary rate limit, a GraphQL RATE_LIMITED error, or a transient
+// network error.
+func isRetryable(err error) bool {
+	var rl *secondaryRateLimitError
+	if errors.As(err, &rl) {
+		return true
+	}
+	var prl *primaryRateLimitError
+	if errors.As(err, &prl) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the wait GitHub asked for via a secondaryRateLimitError,
+// or false if err doesn't carry one.
+func retryAfter(err error) (time.Duration, bool) {
+	var rl *secondaryRateLimitError
+	if errors.As(err, &rl) {
+		return rl.retryAfter, true
+	}
+	return 0, false
+}
+
+// withRetry calls fn up to maxRetries+1 times, retrying only errors
+// isRetryable accepts. It honors an explicit Retry-After wait when present,
+// and otherwise backs off exponentially with jitter, logging year/queryType
+// and the attempt number before each retry.
+func withRetry(ctx context.Context, maxRetries int, year int, queryType string, fn func() error) error {
+	backoff := time.Second
+
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if !isRetryable(err) || attempt == maxRetries {
+			break
+		}
+
+		wait, explicit := retryAfter(err)
+		if !explicit {
+			wait = backoff + time.Duration(rand.Int63n(int64(backoff)))
+			backoff *= 2
+		}
+
+		log.Printf("retrying %s query for year %d (attempt %d/%d) in %s: %v", queryType, year, attempt+1, maxRetries, wait, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return fmt.Errorf("giving up after %d retries: %w", maxRetries, err)
+}
+
+// parseRetryAfterSeconds parses an HTTP Retry-After header value expressed
+// in seconds (GitHub's abuse rate limit always sends this form).
+func parseRetryAfterSeconds(header string) (time.Duration, bool) {
+	secs, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
@@ -0,0 +1,168 @@
+package contrib
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"secondary rate limit", &secondaryRateLimitError{retryAfter: time.Second}, true},
+		{"primary rate limit", &primaryRateLimitError{message: "API rate limit exceeded"}, true},
+		{"network error", &net.DNSError{IsTimeout: true}, true},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tc := range tests {
+		if got := isRetryable(tc.err); got != tc.want {
+			t.Errorf("%s: isRetryable() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestIsRetryableDetectsRealGraphQLRateLimitedError drives a query through
+// an actual githubv4.Client rather than hand-constructing an error, since
+// shurcooL/graphql's own error type discards the "type" field a hand-rolled
+// errors.New("graphql: RATE_LIMITED: ...") would smuggle in: it only ever
+// keeps "message", which never contains the literal string "RATE_LIMITED".
+func TestIsRetryableDetectsRealGraphQLRateLimitedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded for installation ID 123456."}]}`))
+	}))
+	defer server.Close()
+
+	client := githubv4.NewEnterpriseClient(server.URL, WrapHTTPClient(server.Client()))
+
+	var q struct {
+		Viewer struct {
+			Login githubv4.String
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	if err == nil {
+		t.Fatalf("expected an error from a RATE_LIMITED response")
+	}
+	if !isRetryable(err) {
+		t.Fatalf("isRetryable(%v) = false, want true for a real RATE_LIMITED GraphQL response", err)
+	}
+	if !strings.Contains(err.Error(), "API rate limit exceeded for installation ID 123456.") {
+		t.Fatalf("expected the original GraphQL message to be preserved, got %q", err.Error())
+	}
+}
+
+// TestIsRetryableDoesNotRetryRealNonRateLimitGraphQLError guards against the
+// transport-layer detection being too broad: a GraphQL error of any other
+// type must still be treated as permanent.
+func TestIsRetryableDoesNotRetryRealNonRateLimitGraphQLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a User with the login of 'ghost'."}]}`))
+	}))
+	defer server.Close()
+
+	client := githubv4.NewEnterpriseClient(server.URL, WrapHTTPClient(server.Client()))
+
+	var q struct {
+		Viewer struct {
+			Login githubv4.String
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	if err == nil {
+		t.Fatalf("expected an error from a NOT_FOUND response")
+	}
+	if isRetryable(err) {
+		t.Fatalf("isRetryable(%v) = true, want false for a non-rate-limit GraphQL error", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	wait, ok := retryAfter(&secondaryRateLimitError{retryAfter: 30 * time.Second})
+	if !ok || wait != 30*time.Second {
+		t.Errorf("retryAfter() = %v, %v; want 30s, true", wait, ok)
+	}
+
+	if _, ok := retryAfter(errors.New("boom")); ok {
+		t.Errorf("retryAfter() on a plain error should report false")
+	}
+}
+
+func TestParseRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"5", 5 * time.Second, true},
+		{" 12 ", 12 * time.Second, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"not-a-number", 0, false},
+	}
+
+	for _, tc := range tests {
+		got, ok := parseRetryAfterSeconds(tc.header)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("parseRetryAfterSeconds(%q) = %v, %v; want %v, %v", tc.header, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestWithRetrySucceedsAfterTransientErrors(t *testing.T) {
+	attempts := 0
+	err := withRetry(context.Background(), 3, 2023, "commit", func() error {
+		attempts++
+		if attempts < 3 {
+			return &secondaryRateLimitError{retryAfter: time.Millisecond}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withRetry: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestWithRetryGivesUpAfterMaxRetries(t *testing.T) {
+	attempts := 0
+	wantErr := &secondaryRateLimitError{retryAfter: time.Millisecond}
+	err := withRetry(context.Background(), 2, 2023, "commit", func() error {
+		attempts++
+		return wantErr
+	})
+	if err == nil {
+		t.Fatalf("expected an error after exhausting retries")
+	}
+	if attempts != 3 {
+		t.Fatalf("expected maxRetries+1 = 3 attempts, got %d", attempts)
+	}
+}
+
+func TestWithRetryDoesNotRetryNonRetryableError(t *testing.T) {
+	attempts := 0
+	err := withRetry(context.Background(), 5, 2023, "commit", func() error {
+		attempts++
+		return errors.New("permanent failure")
+	})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected a non-retryable error to stop after 1 attempt, got %d", attempts)
+	}
+}
@@ -0,0 +1,177 @@
+// Package render turns the year-by-year contribution map ghcontrib collects
+// into user-facing output: raw JSON, pretty Markdown, HTML, or a
+// user-supplied Go template.
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"text/template"
+)
+
+// Renderer writes reposByYear (year -> repo name -> Repo) to w in some
+// output format.
+type Renderer interface {
+	Render(reposByYear map[int]map[string]Repo, w io.Writer) error
+}
+
+// JSON renders the same flat JSON object ghcontrib has always printed.
+type JSON struct{}
+
+func (JSON) Render(reposByYear map[int]map[string]Repo, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return enc.Encode(reposByYear)
+}
+
+// Markdown renders a year-grouped list of Markdown links, most recent year
+// first. SortBy controls the order of repos within each year: "stars",
+// "pushed", or "name" (the default).
+type Markdown struct {
+	SortBy string
+}
+
+func (m Markdown) Render(reposByYear map[int]map[string]Repo, w io.Writer) error {
+	for _, year := range sortedYearsDesc(reposByYear) {
+		if _, err := fmt.Fprintf(w, "## %d\n\n", year); err != nil {
+			return err
+		}
+		for _, name := range orderedNames(reposByYear[year], m.SortBy) {
+			repo := reposByYear[year][name]
+			if _, err := fmt.Fprintf(w, "- [%s](%s)", html.EscapeString(name), html.EscapeString(repo.URL)); err != nil {
+				return err
+			}
+			if repo.Description != "" {
+				if _, err := fmt.Fprintf(w, " — %s", html.EscapeString(repo.Description)); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HTML renders one collapsible <details> section per year. SortBy controls
+// the order of repos within each year: "stars", "pushed", or "name" (the
+// default).
+type HTML struct {
+	SortBy string
+}
+
+func (h HTML) Render(reposByYear map[int]map[string]Repo, w io.Writer) error {
+	for _, year := range sortedYearsDesc(reposByYear) {
+		if _, err := fmt.Fprintf(w, "<details open>\n<summary>%d</summary>\n<ul>\n", year); err != nil {
+			return err
+		}
+		for _, name := range orderedNames(reposByYear[year], h.SortBy) {
+			repo := reposByYear[year][name]
+			if _, err := fmt.Fprintf(w, "<li><a href=\"%s\">%s</a>", html.EscapeString(repo.URL), html.EscapeString(name)); err != nil {
+				return err
+			}
+			if repo.Description != "" {
+				if _, err := fmt.Fprintf(w, " — %s", html.EscapeString(repo.Description)); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintln(w, "</li>"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, "</ul>\n</details>"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TemplateRepo is the per-repo data made available to a user template under
+// each Year's Repos field.
+type TemplateRepo struct {
+	NameWithOwner string
+	URL           string
+	Stars         int
+	Description   string
+}
+
+// TemplateYear is the per-year data passed to the template's range.
+type TemplateYear struct {
+	Year  int
+	Repos []TemplateRepo
+}
+
+// Template executes a user-supplied text/template against the aggregated
+// data, letting ghcontrib feed README or portfolio generators directly.
+// SortBy controls the order of each Year's Repos: "stars", "pushed", or
+// "name" (the default).
+type Template struct {
+	Tmpl   *template.Template
+	SortBy string
+}
+
+// NewTemplate parses text as a named Go template for use with Template.
+func NewTemplate(text string) (*Template, error) {
+	tmpl, err := template.New("ghcontrib").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	return &Template{Tmpl: tmpl}, nil
+}
+
+func (t *Template) Render(reposByYear map[int]map[string]Repo, w io.Writer) error {
+	var years []TemplateYear
+	for _, year := range sortedYearsDesc(reposByYear) {
+		ty := TemplateYear{Year: year}
+		for _, name := range orderedNames(reposByYear[year], t.SortBy) {
+			repo := reposByYear[year][name]
+			ty.Repos = append(ty.Repos, TemplateRepo{
+				NameWithOwner: name,
+				URL:           repo.URL,
+				Stars:         repo.StargazerCount,
+				Description:   repo.Description,
+			})
+		}
+		years = append(years, ty)
+	}
+	return t.Tmpl.Execute(w, years)
+}
+
+func sortedYearsDesc(reposByYear map[int]map[string]Repo) []int {
+	years := make([]int, 0, len(reposByYear))
+	for year := range reposByYear {
+		years = append(years, year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	return years
+}
+
+// orderedNames returns repos' keys ordered by sortBy: "stars" (highest
+// first), "pushed" (most recently pushed first), or "name" (alphabetical,
+// also the fallback for an unrecognized value).
+func orderedNames(repos map[string]Repo, sortBy string) []string {
+	names := make([]string, 0, len(repos))
+	for name := range repos {
+		names = append(names, name)
+	}
+
+	switch sortBy {
+	case "stars":
+		sort.Slice(names, func(i, j int) bool {
+			return repos[names[i]].StargazerCount > repos[names[j]].StargazerCount
+		})
+	case "pushed":
+		sort.Slice(names, func(i, j int) bool {
+			return repos[names[i]].PushedAt.Time.After(repos[names[j]].PushedAt.Time)
+		})
+	default:
+		sort.Strings(names)
+	}
+	return names
+}
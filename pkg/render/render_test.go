@@ -0,0 +1,167 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func repoSet() map[int]map[string]Repo {
+	return map[int]map[string]Repo{
+		2023: {
+			"jmoney/a": {URL: "https://github.com/jmoney/a", StargazerCount: 1, PushedAt: githubv4.DateTime{Time: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)}},
+			"jmoney/b": {URL: "https://github.com/jmoney/b", StargazerCount: 5, PushedAt: githubv4.DateTime{Time: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+	}
+}
+
+func TestOrderedNames(t *testing.T) {
+	repos := repoSet()[2023]
+
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{"name", []string{"jmoney/a", "jmoney/b"}},
+		{"stars", []string{"jmoney/b", "jmoney/a"}},
+		{"pushed", []string{"jmoney/b", "jmoney/a"}},
+		{"unknown", []string{"jmoney/a", "jmoney/b"}},
+	}
+
+	for _, tc := range tests {
+		got := orderedNames(repos, tc.sortBy)
+		if len(got) != len(tc.want) {
+			t.Fatalf("sortBy=%q: got %v, want %v", tc.sortBy, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("sortBy=%q: got %v, want %v", tc.sortBy, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestHTMLRenderEscapesUntrustedFields(t *testing.T) {
+	reposByYear := map[int]map[string]Repo{
+		2023: {
+			"jmoney/<script>": {
+				URL:         `https://example.com/"><script>alert(1)</script>`,
+				Description: "<img src=x onerror=alert(1)>",
+			},
+		},
+	}
+
+	var buf strings.Builder
+	if err := (HTML{}).Render(reposByYear, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "<script>") || strings.Contains(out, "<img ") {
+		t.Fatalf("Render did not escape untrusted HTML, got: %s", out)
+	}
+}
+
+func TestJSONRenderRoundTrips(t *testing.T) {
+	var buf strings.Builder
+	if err := (JSON{}).Render(repoSet(), &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(buf.String(), "jmoney/a") {
+		t.Fatalf("expected rendered JSON to contain repo name, got: %s", buf.String())
+	}
+}
+
+func TestMarkdownRenderEscapesUntrustedFields(t *testing.T) {
+	reposByYear := map[int]map[string]Repo{
+		2023: {
+			"jmoney/<script>": {
+				URL:         `https://example.com/"><script>alert(1)</script>`,
+				Description: "<img src=x onerror=alert(1)>",
+			},
+		},
+	}
+
+	var buf strings.Builder
+	if err := (Markdown{}).Render(reposByYear, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "<script>") || strings.Contains(out, "<img ") {
+		t.Fatalf("Render did not escape untrusted HTML, got: %s", out)
+	}
+}
+
+func TestTemplateRenderExecutesUserTemplate(t *testing.T) {
+	reposByYear := map[int]map[string]Repo{
+		2022: {"jmoney/old": {URL: "https://github.com/jmoney/old", StargazerCount: 1, Description: "an old repo"}},
+		2023: {"jmoney/new": {URL: "https://github.com/jmoney/new", StargazerCount: 9, Description: "a new repo"}},
+	}
+
+	tmpl, err := NewTemplate(`{{range .}}{{.Year}}:{{range .Repos}} {{.NameWithOwner}} ({{.URL}}, {{.Stars}} stars, {{.Description}}){{end}}
+{{end}}`)
+	if err != nil {
+		t.Fatalf("NewTemplate: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Render(reposByYear, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	want := "2023: jmoney/new (https://github.com/jmoney/new, 9 stars, a new repo)\n" +
+		"2022: jmoney/old (https://github.com/jmoney/old, 1 stars, an old repo)\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("Render = %q, want %q", got, want)
+	}
+}
+
+func TestTemplateRenderAppliesSortBy(t *testing.T) {
+	reposByYear := map[int]map[string]Repo{
+		2023: {
+			"jmoney/a": {StargazerCount: 1},
+			"jmoney/b": {StargazerCount: 5},
+		},
+	}
+
+	tmpl, err := NewTemplate(`{{range .}}{{range .Repos}}{{.NameWithOwner}} {{end}}{{end}}`)
+	if err != nil {
+		t.Fatalf("NewTemplate: %v", err)
+	}
+	tmpl.SortBy = "stars"
+
+	var buf strings.Builder
+	if err := tmpl.Render(reposByYear, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "jmoney/b jmoney/a "; buf.String() != want {
+		t.Fatalf("Render = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNewTemplateRejectsInvalidSyntax(t *testing.T) {
+	if _, err := NewTemplate(`{{.Unclosed`); err == nil {
+		t.Fatalf("expected an error parsing invalid template syntax")
+	}
+}
+
+func TestMarkdownRenderGroupsByYearDescending(t *testing.T) {
+	reposByYear := map[int]map[string]Repo{
+		2022: {"jmoney/old": {URL: "https://github.com/jmoney/old"}},
+		2023: {"jmoney/new": {URL: "https://github.com/jmoney/new"}},
+	}
+
+	var buf strings.Builder
+	if err := (Markdown{}).Render(reposByYear, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Index(out, "## 2023") > strings.Index(out, "## 2022") {
+		t.Fatalf("expected 2023 section before 2022 section, got: %s", out)
+	}
+}
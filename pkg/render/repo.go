@@ -0,0 +1,22 @@
+package render
+
+import "github.com/shurcooL/githubv4"
+
+// Repo is a single contributed-to repository, enriched with enough metadata
+// for renderers to group, sort, or filter without a second API round trip.
+type Repo struct {
+	NameWithOwner   string
+	URL             string
+	IsPrivate       bool
+	Description     string
+	StargazerCount  int
+	PrimaryLanguage struct {
+		Name  string
+		Color string
+	}
+	Owner struct {
+		Login     string
+		AvatarUrl string
+	}
+	PushedAt githubv4.DateTime
+}
@@ -0,0 +1,256 @@
+// Package server wraps ghcontrib's contribution collector behind an HTTP
+// API, so it can run embedded behind a portfolio site instead of as a
+// one-off CLI invocation.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoney/ghcontrib/pkg/auth"
+	"github.com/jmoney/ghcontrib/pkg/cache"
+	"github.com/jmoney/ghcontrib/pkg/contrib"
+	"github.com/jmoney/ghcontrib/pkg/render"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+)
+
+// defaultMaxRetries matches the CLI's --max-retries default, so queries
+// issued by the serve command also retry transient and rate-limit failures.
+const defaultMaxRetries = 5
+
+// maxYearRange bounds start..end so a request like ?start=0&end=999999 can't
+// fan out an unbounded number of per-year goroutines and GraphQL calls.
+const maxYearRange = 15
+
+// Server exposes GET /contrib/{username} and the device-flow login
+// endpoints used to authorize it.
+type Server struct {
+	cache    *cache.Cache
+	cacheDir string
+	clientID string
+	mux      *http.ServeMux
+}
+
+// New builds a Server that caches GraphQL responses in c (rooted at
+// cacheDir) and authorizes device-flow logins against the OAuth App
+// identified by clientID.
+func New(c *cache.Cache, cacheDir, clientID string) *Server {
+	s := &Server{cache: c, cacheDir: cacheDir, clientID: clientID, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/contrib/", s.handleContrib)
+	s.mux.HandleFunc("/auth/device", s.handleDeviceLogin)
+	return s
+}
+
+// ListenAndServe starts the HTTP server on addr.
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s.mux)
+}
+
+// handleContrib serves GET /contrib/{username}?start=YYYY&end=YYYY&format=json|md|html&min-stars=N&language=go&sort-by=stars.
+// With no format (or format=ndjson) it streams one NDJSON line per year as
+// results become available; any other format buffers the full result and
+// renders it the same way the CLI's --format flag would.
+func (s *Server) handleContrib(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Path[len("/contrib/"):]
+	if username == "" {
+		http.Error(w, "missing username", http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.LoadToken(s.cacheDir, username)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("loading token: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		http.Error(w, fmt.Sprintf("no token on file for %s; POST /auth/device?username=%s to authorize", username, username), http.StatusUnauthorized)
+		return
+	}
+
+	startYear, err := queryYear(r, "start", time.Now().Year())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	endYear, err := queryYear(r, "end", time.Now().Year())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if endYear < startYear || endYear-startYear+1 > maxYearRange {
+		http.Error(w, fmt.Sprintf("start..end must span at most %d years", maxYearRange), http.StatusBadRequest)
+		return
+	}
+
+	minStars, err := queryInt(r, "min-stars", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	language := r.URL.Query().Get("language")
+	sortBy := r.URL.Query().Get("sort-by")
+	if sortBy == "" {
+		sortBy = "name"
+	}
+
+	format := r.URL.Query().Get("format")
+	var renderer render.Renderer
+	if format != "" && format != "ndjson" {
+		renderer, err = rendererFor(format, sortBy)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	httpClient := contrib.WrapHTTPClient(oauth2.NewClient(r.Context(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})))
+	client := githubv4.NewClient(httpClient)
+
+	reposByYear, err := contrib.Collect(r.Context(), client, s.cache, contrib.Options{
+		Username:   username,
+		StartYear:  startYear,
+		EndYear:    endYear,
+		MinStars:   minStars,
+		Language:   language,
+		MaxRetries: defaultMaxRetries,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("collecting contributions: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	if renderer != nil {
+		w.Header().Set("Content-Type", contentTypeFor(format))
+		if err := renderer.Render(reposByYear, w); err != nil {
+			log.Printf("rendering %s contributions for %s: %v", format, username, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	// reposByYear isn't confined to [startYear, endYear]: repositoriesContributedTo
+	// results are always attributed to the current year, which can fall outside
+	// the requested range. Stream every year Collect returned rather than just
+	// the requested span, so those results aren't silently dropped.
+	for _, year := range sortedYears(reposByYear) {
+		if err := enc.Encode(map[string]interface{}{"year": year, "repos": reposByYear[year]}); err != nil {
+			log.Printf("streaming year %d for %s: %v", year, username, err)
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// rendererFor builds the render.Renderer named by format ("json", "md"/
+// "markdown", or "html"), applying sortBy to formats that render repos in a
+// visible order.
+func rendererFor(format, sortBy string) (render.Renderer, error) {
+	switch format {
+	case "json":
+		return render.JSON{}, nil
+	case "md", "markdown":
+		return render.Markdown{SortBy: sortBy}, nil
+	case "html":
+		return render.HTML{SortBy: sortBy}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q (valid: json, md, html)", format)
+	}
+}
+
+// contentTypeFor returns the response Content-Type for a validated format
+// string, as returned by rendererFor.
+func contentTypeFor(format string) string {
+	switch format {
+	case "json":
+		return "application/json"
+	case "html":
+		return "text/html; charset=utf-8"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// handleDeviceLogin starts the OAuth device flow for ?username=, returning
+// the code the user must enter at the verification URI. A background
+// goroutine polls for completion and caches the resulting token.
+func (s *Server) handleDeviceLogin(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		http.Error(w, "missing username", http.StatusBadRequest)
+		return
+	}
+
+	dfc := auth.NewDeviceFlowClient(s.clientID)
+	dc, err := dfc.RequestCode(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("requesting device code: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(dc.ExpiresIn)*time.Second)
+		defer cancel()
+
+		token, err := dfc.PollForToken(ctx, dc)
+		if err != nil {
+			log.Printf("device flow login for %s failed: %v", username, err)
+			return
+		}
+		if err := auth.SaveToken(s.cacheDir, username, token); err != nil {
+			log.Printf("caching token for %s: %v", username, err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dc)
+}
+
+// sortedYears returns reposByYear's keys in ascending order, so NDJSON output
+// is deterministic even though Collect may return years outside the
+// requested start..end span.
+func sortedYears(reposByYear map[int]map[string]render.Repo) []int {
+	years := make([]int, 0, len(reposByYear))
+	for year := range reposByYear {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+	return years
+}
+
+func queryYear(r *http.Request, param string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(param)
+	if raw == "" {
+		return fallback, nil
+	}
+	year, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s=%q: %w", param, raw, err)
+	}
+	return year, nil
+}
+
+func queryInt(r *http.Request, param string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(param)
+	if raw == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s=%q: %w", param, raw, err)
+	}
+	return n, nil
+}
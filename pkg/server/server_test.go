@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jmoney/ghcontrib/pkg/auth"
+	"github.com/jmoney/ghcontrib/pkg/cache"
+)
+
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	c, err := cache.New(dir)
+	if err != nil {
+		t.Fatalf("cache.New: %v", err)
+	}
+	return New(c, dir, "test-client-id"), dir
+}
+
+func TestHandleContribMissingUsername(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/contrib/", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleContribNoTokenOnFile(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/contrib/jmoney", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleContribInvalidYearParam(t *testing.T) {
+	s, dir := newTestServer(t)
+	if err := auth.SaveToken(dir, "jmoney", "token"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/contrib/jmoney?start=not-a-year", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleContribRejectsOverlongYearRange(t *testing.T) {
+	s, dir := newTestServer(t)
+	if err := auth.SaveToken(dir, "jmoney", "token"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/contrib/jmoney?start=2000&end=2026", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleContribRejectsEndBeforeStart(t *testing.T) {
+	s, dir := newTestServer(t)
+	if err := auth.SaveToken(dir, "jmoney", "token"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/contrib/jmoney?start=2024&end=2020", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleContribRejectsUnknownFormat(t *testing.T) {
+	s, dir := newTestServer(t)
+	if err := auth.SaveToken(dir, "jmoney", "token"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/contrib/jmoney?format=yaml", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}